@@ -10,14 +10,29 @@ import (
 
 // TODO:
 // - Support AuthZ
-// - Support Digest.
 
-// AuthN is an authentication provider.
+// AuthN is an authentication provider for a single auth-scheme (Basic,
+// Digest, Bearer, or a custom scheme).
 //
 // It is responsible for taking raw HTTP Authorization data and determining
 // whether the request is authenticated.
 type AuthN interface {
-	Authenticate(string) (bool, error)
+	// Scheme returns the auth-scheme name this provider handles, e.g.
+	// "Basic" or "Digest". Lookup against the scheme sent by the client is
+	// case-insensitive.
+	Scheme() string
+
+	// Authenticate takes the original *http.Request and the Authorization
+	// header value with the auth-scheme prefix already stripped (so for
+	// "Authorization: Basic Zm9v", data is "Zm9v"). Providers that need to
+	// expose additional data to downstream handlers (e.g. parsed JWT
+	// claims) may attach it to req's context and replace *req with the
+	// updated request.
+	Authenticate(req *http.Request, data string) (bool, error)
+
+	// Challenge returns this provider's contribution to the
+	// WWW-Authenticate header sent on a 401, e.g. `Basic realm="secret"`.
+	Challenge(realm string) string
 }
 
 // UserPasswordLookup provides services for lookup up a username/password combo.
@@ -34,8 +49,13 @@ type BasicAuth struct {
 	Users UserPasswordLookup
 }
 
+// Scheme identifies this provider as "Basic".
+func (a *BasicAuth) Scheme() string {
+	return "Basic"
+}
+
 // Authenticate performs an authentication step on raw HTTP Authorization data.
-func (a *BasicAuth) Authenticate(data string) (bool, error) {
+func (a *BasicAuth) Authenticate(req *http.Request, data string) (bool, error) {
 	user, pass, err := parseBasicString(data)
 	if err != nil {
 		return false, fmt.Errorf("Basic authentication parsing failed: %s", err)
@@ -44,20 +64,40 @@ func (a *BasicAuth) Authenticate(data string) (bool, error) {
 	return a.Users.IsValid(user, pass)
 }
 
+// Challenge returns this provider's WWW-Authenticate contribution.
+func (a *BasicAuth) Challenge(realm string) string {
+	return fmt.Sprintf(`Basic realm="%s"`, realm)
+}
+
 // Create a new HTTPAuth object with HTTP Basic support.
 //
 // This requires a UserPasswordLookup service.
 func NewBasicHTTPAuth(pwdb UserPasswordLookup) *HTTPAuth {
+	return NewHTTPAuth(&BasicAuth{Users: pwdb})
+}
+
+// NewHTTPAuth creates an HTTPAuth that dispatches to whichever of providers
+// matches the auth-scheme sent by the client. When more than one provider
+// is given, a failed or missing Authorization header produces a combined
+// WWW-Authenticate header listing all of their challenges, so the client can
+// choose which scheme to retry with.
+func NewHTTPAuth(providers ...AuthN) *HTTPAuth {
+	auths := make(map[string]AuthN, len(providers))
+	for _, p := range providers {
+		auths[strings.ToLower(p.Scheme())] = p
+	}
 	return &HTTPAuth{
-		Realm: "secret",
-		auths: map[string]AuthN{"basic": &BasicAuth{Users: pwdb}},
+		Realm:     "secret",
+		auths:     auths,
+		providers: providers,
 	}
 }
 
 // HTTPAuth provides HTTP authentication services.
 type HTTPAuth struct {
-	Realm string
-	auths map[string]AuthN
+	Realm     string
+	auths     map[string]AuthN
+	providers []AuthN // preserves registration order for Challenge output
 }
 
 // This will attempt to authenticate, and return an HTTP error if auth fails.
@@ -66,50 +106,55 @@ type HTTPAuth struct {
 func (h *HTTPAuth) Authenticate(res http.ResponseWriter, req *http.Request) bool {
 	authz := strings.TrimSpace(req.Header.Get("Authorization"))
 
-	// FIXME: This should extract the authn type and look it up in the auths map.
-	if len(authz) == 0 || !strings.Contains(authz, "Basic ") {
-		sendUnauthorized(h.Realm, res)
+	scheme, data, ok := strings.Cut(authz, " ")
+	if !ok {
+		sendUnauthorized(h.Realm, h.providers, res)
 		return false
 	}
-	authn, ok := h.auths["basic"]
+
+	authn, ok := h.auths[strings.ToLower(scheme)]
 	if !ok {
-		sendUnauthorized(h.Realm, res)
+		sendUnauthorized(h.Realm, h.providers, res)
 		return false
 	}
-	// END fixme
 
-	if ok, _ := authn.Authenticate(authz); !ok {
-		sendUnauthorized(h.Realm, res)
+	if ok, _ := authn.Authenticate(req, data); !ok {
+		sendUnauthorized(h.Realm, h.providers, res)
 		return false
 	}
 
-	return ok
+	return true
 }
 
-func parseBasicString(header string) (user, pass string, err error) {
-	parts := strings.Split(header, " ")
-	user = ""
-	pass = ""
-	if len(parts) < 2 {
+// parseBasicString decodes the base64 credentials that follow the "Basic "
+// scheme prefix in an Authorization header (the prefix itself is expected
+// to already have been stripped by the caller).
+func parseBasicString(data string) (user, pass string, err error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
 		err = errors.New("No auth string found.")
 		return
 	}
 
-	full, err := base64.StdEncoding.DecodeString(parts[1])
+	full, err := base64.StdEncoding.DecodeString(data)
 	if err != nil {
 		return
 	}
 
-	parts = strings.SplitN(string(full), ":", 2)
+	parts := strings.SplitN(string(full), ":", 2)
 	user = parts[0]
-	if len(parts) > 0 {
+	if len(parts) > 1 {
 		pass = parts[1]
 	}
 	return
 }
 
-func sendUnauthorized(realm string, res http.ResponseWriter) {
-	// Send a 403
-	res.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=\"%s\"", realm))
+func sendUnauthorized(realm string, providers []AuthN, res http.ResponseWriter) {
+	if len(providers) == 0 {
+		res.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
+	}
+	for _, p := range providers {
+		res.Header().Add("WWW-Authenticate", p.Challenge(realm))
+	}
 	http.Error(res, "Authentication Required", http.StatusUnauthorized)
 }
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type staticUserLookup map[string]string
+
+func (m staticUserLookup) IsValid(user, pass string) (bool, error) {
+	return m[user] == pass, nil
+}
+
+func TestHTTPAuthDispatchesByScheme(t *testing.T) {
+	h := NewHTTPAuth(
+		&BasicAuth{Users: staticUserLookup{"ahab": "whale"}},
+		&BearerAuth{KeyFunc: func(map[string]interface{}) (interface{}, error) {
+			return []byte("secret"), nil
+		}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.SetBasicAuth("ahab", "whale")
+	rec := httptest.NewRecorder()
+	if ok := h.Authenticate(rec, req); !ok {
+		t.Fatalf("expected Basic credentials to authenticate, got status %d", rec.Code)
+	}
+}
+
+func TestHTTPAuthRejectsUnknownScheme(t *testing.T) {
+	h := NewHTTPAuth(&BasicAuth{Users: staticUserLookup{"ahab": "whale"}})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "Digest response=bogus")
+	rec := httptest.NewRecorder()
+	if ok := h.Authenticate(rec, req); ok {
+		t.Fatal("expected an unregistered scheme to be rejected")
+	}
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+}
+
+func TestHTTPAuthChallengeListsAllRegisteredSchemes(t *testing.T) {
+	h := NewHTTPAuth(
+		&BasicAuth{Users: staticUserLookup{}},
+		&DigestAuth{Users: staticDigestLookup{}},
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	h.Authenticate(rec, req)
+
+	challenges := rec.Header().Values("WWW-Authenticate")
+	if len(challenges) != 2 {
+		t.Fatalf("expected 2 WWW-Authenticate challenges, got %d: %v", len(challenges), challenges)
+	}
+}
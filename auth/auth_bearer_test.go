@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const bearerTestSecret = "shh-its-a-secret"
+
+func signHS256(t *testing.T, claims Claims) string {
+	t.Helper()
+
+	header, err := json.Marshal(map[string]interface{}{"alg": "HS256", "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(bearerTestSecret))
+	mac.Write([]byte(signingInput))
+	sig := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return signingInput + "." + sig
+}
+
+func hs256KeyFunc(map[string]interface{}) (interface{}, error) {
+	return []byte(bearerTestSecret), nil
+}
+
+func TestBearerAuthAcceptsValidToken(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc, Issuer: "https://issuer.test", Audience: "client-1"}
+
+	tok := signHS256(t, Claims{
+		"iss": "https://issuer.test",
+		"aud": "client-1",
+		"exp": float64(time.Now().Add(time.Hour).Unix()),
+		"nbf": float64(time.Now().Add(-time.Hour).Unix()),
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ok, err := a.Authenticate(req, tok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected a correctly signed, claims-valid token to authenticate")
+	}
+}
+
+func TestBearerAuthRejectsExpiredToken(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc}
+
+	tok := signHS256(t, Claims{"exp": float64(time.Now().Add(-time.Hour).Unix())})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ok, err := a.Authenticate(req, tok); err == nil || ok {
+		t.Fatalf("expected an expired token to be rejected, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestBearerAuthRejectsNotYetValidToken(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc}
+
+	tok := signHS256(t, Claims{"nbf": float64(time.Now().Add(time.Hour).Unix())})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ok, err := a.Authenticate(req, tok); err == nil || ok {
+		t.Fatalf("expected a not-yet-valid token to be rejected, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestBearerAuthRejectsWrongIssuer(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc, Issuer: "https://issuer.test"}
+
+	tok := signHS256(t, Claims{"iss": "https://evil.test"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ok, err := a.Authenticate(req, tok); err == nil || ok {
+		t.Fatalf("expected a mismatched issuer to be rejected, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestBearerAuthRejectsWrongAudience(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc, Audience: "client-1"}
+
+	tok := signHS256(t, Claims{"aud": "client-2"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ok, err := a.Authenticate(req, tok); err == nil || ok {
+		t.Fatalf("expected a mismatched audience to be rejected, got ok=%v err=%v", ok, err)
+	}
+}
+
+func TestBearerAuthAttachesClaimsToContext(t *testing.T) {
+	a := &BearerAuth{KeyFunc: hs256KeyFunc}
+	tok := signHS256(t, Claims{"sub": "ishmael"})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if ok, err := a.Authenticate(req, tok); err != nil || !ok {
+		t.Fatalf("expected token to authenticate, got ok=%v err=%v", ok, err)
+	}
+
+	claims, ok := ClaimsFromContext(req.Context())
+	if !ok {
+		t.Fatal("expected claims to be attached to the request context")
+	}
+	if claims["sub"] != "ishmael" {
+		t.Errorf("expected sub=ishmael, got %v", claims["sub"])
+	}
+}
@@ -0,0 +1,411 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// stateCookieName is the cookie LoginHandler uses to bind the CSRF state
+// value to the browser that started the login, so CallbackHandler can
+// detect a forged or replayed callback.
+const stateCookieName = "oauth2_state"
+
+// sessionCookieName is the cookie CallbackHandler sets once OnLogin
+// succeeds, and what SessionAuth reads on subsequent requests.
+const sessionCookieName = "session"
+
+// OnLogin is invoked by CallbackHandler once an ExternalUser has been
+// resolved, and is responsible for mapping it to a local account (creating
+// one if this is the external user's first login).
+type OnLogin func(ctx context.Context, user ExternalUser) (localUserID string, err error)
+
+// LoginHandler returns an http.Handler that starts the authorization-code
+// flow for the provider registered as providerName: it issues a random
+// CSRF state value, stores it in a cookie, and redirects the browser to the
+// provider's AuthURL. The state value is not signed; the callback only
+// checks that the state query parameter equals the cookie value.
+func LoginHandler(providerName string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := lookup(providerName)
+		if !ok {
+			http.Error(w, "oauth2: unknown provider", http.StatusNotFound)
+			return
+		}
+
+		state, err := newState()
+		if err != nil {
+			http.Error(w, "oauth2: failed to start login", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookieName,
+			Value:    state,
+			Path:     "/",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, p.authorizeURL(state), http.StatusFound)
+	})
+}
+
+// CallbackHandler returns an http.Handler that completes the
+// authorization-code flow for providerName: it validates the CSRF state,
+// exchanges the code for a token, resolves an ExternalUser, invokes
+// onLogin, and, on success, starts a session via sessions and sets the
+// session cookie.
+//
+// If accounts is non-nil, CallbackHandler checks it for an existing link
+// before calling onLogin: a returning user (one whose provider+external ID
+// is already linked) is signed straight into the local account the link
+// points to, and onLogin is only invoked for a first-time login, with the
+// resulting localUserID recorded via accounts.Link so the next callback
+// recognizes them. Pass a nil accounts to skip linking and always resolve
+// the local user through onLogin, as before.
+func CallbackHandler(providerName string, onLogin OnLogin, sessions SessionStore, accounts LinkedAccountStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p, ok := lookup(providerName)
+		if !ok {
+			http.Error(w, "oauth2: unknown provider", http.StatusNotFound)
+			return
+		}
+
+		if err := checkState(r); err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		clearStateCookie(w)
+
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "oauth2: missing code", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+
+		token, err := exchangeCode(ctx, p, code)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		user, err := resolveExternalUser(ctx, p, token)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		localUserID, err := resolveLocalUser(ctx, accounts, p.Name, user, onLogin)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		sessionID, err := sessions.Create(ctx, localUserID)
+		if err != nil {
+			http.Error(w, "oauth2: failed to start session", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookieName,
+			Value:    sessionID,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+// resolveLocalUser maps user to a local user ID, consulting accounts for an
+// existing link first so a returning user bypasses onLogin entirely. If
+// accounts is nil, or this is the external account's first login, onLogin
+// resolves the local user ID and, when accounts is non-nil, the link is
+// recorded for next time.
+func resolveLocalUser(ctx context.Context, accounts LinkedAccountStore, provider string, user ExternalUser, onLogin OnLogin) (string, error) {
+	if accounts != nil {
+		if localUserID, ok, err := accounts.FindLocalUser(ctx, provider, user.ID); err != nil {
+			return "", err
+		} else if ok {
+			return localUserID, nil
+		}
+	}
+
+	localUserID, err := onLogin(ctx, user)
+	if err != nil {
+		return "", err
+	}
+
+	if accounts != nil {
+		if err := accounts.Link(ctx, localUserID, provider, user.ID); err != nil {
+			return "", err
+		}
+	}
+
+	return localUserID, nil
+}
+
+// LinkHandler returns an http.Handler that completes the authorization-code
+// flow for providerName and attaches the resulting external account to the
+// caller's already-authenticated local account, rather than starting a new
+// session. It must run behind auth.HTTPAuth's SessionAuth (or equivalent),
+// so that UserIDFromContext resolves the current user.
+func LinkHandler(providerName string, accounts LinkedAccountStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		localUserID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(w, "oauth2: linking requires an authenticated session", http.StatusUnauthorized)
+			return
+		}
+
+		p, ok := lookup(providerName)
+		if !ok {
+			http.Error(w, "oauth2: unknown provider", http.StatusNotFound)
+			return
+		}
+
+		if err := checkState(r); err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		clearStateCookie(w)
+
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "oauth2: missing code", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+
+		token, err := exchangeCode(ctx, p, code)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		user, err := resolveExternalUser(ctx, p, token)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		if err := accounts.Link(ctx, localUserID, p.Name, user.ID); err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+// UnlinkHandler returns an http.Handler that removes the caller's linked
+// external account for providerName, so long as the link belongs to the
+// caller's own authenticated local account. It must run behind the same
+// session authentication as LinkHandler.
+//
+// Unlike LoginHandler/CallbackHandler/LinkHandler, this is an
+// application-initiated action rather than an OAuth2 redirect target, so it
+// has no state cookie of its own to guard against forgery. It therefore
+// only accepts POST, reads external_id from the request body rather than
+// the query string, and should be mounted behind csrf.Protect (or
+// equivalent) like any other state-changing handler, since SameSite=Lax
+// session cookies are still sent on cross-site top-level GET navigation.
+func UnlinkHandler(providerName string, accounts LinkedAccountStore) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "oauth2: method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		localUserID, ok := UserIDFromContext(r.Context())
+		if !ok {
+			http.Error(w, "oauth2: unlinking requires an authenticated session", http.StatusUnauthorized)
+			return
+		}
+
+		externalID := r.PostFormValue("external_id")
+		if externalID == "" {
+			http.Error(w, "oauth2: missing external_id", http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+
+		owner, ok, err := accounts.FindLocalUser(ctx, providerName, externalID)
+		if err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !ok || owner != localUserID {
+			http.Error(w, "oauth2: no such linked account", http.StatusNotFound)
+			return
+		}
+
+		if err := accounts.Unlink(ctx, providerName, externalID); err != nil {
+			http.Error(w, "oauth2: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, "/", http.StatusFound)
+	})
+}
+
+func (p *Provider) authorizeURL(state string) string {
+	u, err := url.Parse(p.AuthURL)
+	if err != nil {
+		return p.AuthURL
+	}
+
+	q := u.Query()
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("response_type", "code")
+	if len(p.Scopes) > 0 {
+		q.Set("scope", strings.Join(p.Scopes, " "))
+	}
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func newState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func checkState(r *http.Request) error {
+	cookie, err := r.Cookie(stateCookieName)
+	if err != nil {
+		return errors.New("missing state cookie")
+	}
+	if cookie.Value == "" || cookie.Value != r.URL.Query().Get("state") {
+		return errors.New("state mismatch")
+	}
+	return nil
+}
+
+func clearStateCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{Name: stateCookieName, Value: "", Path: "/", MaxAge: -1})
+}
+
+// tokenResponse is the subset of RFC 6749 section 5.1's access token
+// response this package cares about.
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+}
+
+func exchangeCode(ctx context.Context, p *Provider, code string) (*tokenResponse, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token exchange failed: %s: %s", resp.Status, body)
+	}
+
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("decoding token response: %s", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, errors.New("token response did not include an access_token")
+	}
+	return &tok, nil
+}
+
+func resolveExternalUser(ctx context.Context, p *Provider, tok *tokenResponse) (ExternalUser, error) {
+	// Prefer the OIDC ID token when the provider supports one: it's
+	// already signed by the provider, so no extra round trip is needed to
+	// trust it.
+	if tok.IDToken != "" && p.jwks != nil {
+		claims, err := verifyIDToken(p, tok.IDToken)
+		if err != nil {
+			return ExternalUser{}, err
+		}
+		return mapClaims(p, claims), nil
+	}
+
+	if p.UserInfoURL == "" {
+		return ExternalUser{}, errors.New("provider has neither an id_token nor a UserInfoURL")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return ExternalUser{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+tok.AccessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return ExternalUser{}, fmt.Errorf("userinfo request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return ExternalUser{}, fmt.Errorf("userinfo request failed: %s", resp.Status)
+	}
+
+	var raw map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return ExternalUser{}, fmt.Errorf("decoding userinfo response: %s", err)
+	}
+
+	return mapClaims(p, raw), nil
+}
+
+func mapClaims(p *Provider, raw map[string]interface{}) ExternalUser {
+	if p.ClaimsMapper == nil {
+		return ExternalUser{Provider: p.Name, Raw: raw}
+	}
+	user := p.ClaimsMapper(raw)
+	user.Provider = p.Name
+	if user.Raw == nil {
+		user.Raw = raw
+	}
+	return user
+}
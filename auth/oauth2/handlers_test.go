@@ -0,0 +1,423 @@
+package oauth2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// memSessionStore is a minimal in-process SessionStore for tests.
+type memSessionStore struct {
+	sessions map[string]string
+	nextID   int64
+}
+
+func newMemSessionStore() *memSessionStore {
+	return &memSessionStore{sessions: map[string]string{}}
+}
+
+func (s *memSessionStore) Create(ctx context.Context, userID string) (string, error) {
+	id := atomic.AddInt64(&s.nextID, 1)
+	sessionID := fmt.Sprintf("sess-%d", id)
+	s.sessions[sessionID] = userID
+	return sessionID, nil
+}
+
+func (s *memSessionStore) Lookup(ctx context.Context, sessionID string) (string, bool, error) {
+	userID, ok := s.sessions[sessionID]
+	return userID, ok, nil
+}
+
+func (s *memSessionStore) Destroy(ctx context.Context, sessionID string) error {
+	delete(s.sessions, sessionID)
+	return nil
+}
+
+// memLinkedAccountStore is a minimal in-process LinkedAccountStore for tests.
+type memLinkedAccountStore struct {
+	links map[string]string // "provider:externalID" -> localUserID
+}
+
+func newMemLinkedAccountStore() *memLinkedAccountStore {
+	return &memLinkedAccountStore{links: map[string]string{}}
+}
+
+func (s *memLinkedAccountStore) key(provider, externalID string) string {
+	return provider + ":" + externalID
+}
+
+func (s *memLinkedAccountStore) Link(ctx context.Context, localUserID, provider, externalID string) error {
+	s.links[s.key(provider, externalID)] = localUserID
+	return nil
+}
+
+func (s *memLinkedAccountStore) FindLocalUser(ctx context.Context, provider, externalID string) (string, bool, error) {
+	userID, ok := s.links[s.key(provider, externalID)]
+	return userID, ok, nil
+}
+
+func (s *memLinkedAccountStore) Unlink(ctx context.Context, provider, externalID string) error {
+	delete(s.links, s.key(provider, externalID))
+	return nil
+}
+
+// newTestProvider registers a Provider backed by httptest servers standing
+// in for the IdP's token and userinfo endpoints, returning the registered
+// name and a teardown func.
+func newTestProvider(t *testing.T) (name string, teardown func()) {
+	t.Helper()
+
+	userinfo := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-access-token" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"sub":"external-1","name":"Ishmael","email":"ishmael@example.com"}`))
+	}))
+
+	token := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.Form.Get("code") == "" {
+			http.Error(w, "missing code", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"test-access-token","token_type":"bearer"}`))
+	}))
+
+	name = fmt.Sprintf("test-provider-%d", len(registry))
+	Register(name, &Provider{
+		ClientID:     "client-id",
+		ClientSecret: "client-secret",
+		AuthURL:      "https://example.test/authorize",
+		TokenURL:     token.URL,
+		UserInfoURL:  userinfo.URL,
+		RedirectURL:  "https://app.example/callback",
+		ClaimsMapper: func(raw map[string]interface{}) ExternalUser {
+			id, _ := raw["sub"].(string)
+			name, _ := raw["name"].(string)
+			email, _ := raw["email"].(string)
+			return ExternalUser{ID: id, Name: name, Email: email}
+		},
+	})
+
+	return name, func() {
+		token.Close()
+		userinfo.Close()
+	}
+}
+
+// startLogin drives LoginHandler to obtain the state cookie and value it
+// issued, as a stand-in for the browser round trip to the provider and back.
+func startLogin(t *testing.T, providerName string) (*http.Cookie, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/login/"+providerName, nil)
+	LoginHandler(providerName).ServeHTTP(rec, req)
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected LoginHandler to set exactly one cookie, got %d", len(cookies))
+	}
+	stateCookie := cookies[0]
+
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stateCookie, loc.Query().Get("state")
+}
+
+// findCookie returns the cookie named name set on rec, failing the test if
+// it is not present.
+func findCookie(t *testing.T, rec *httptest.ResponseRecorder, name string) *http.Cookie {
+	t.Helper()
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	t.Fatalf("expected a %q cookie to be set, got %+v", name, rec.Result().Cookies())
+	return nil
+}
+
+func TestCallbackHandlerHappyPath(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	stateCookie, state := startLogin(t, providerName)
+	sessions := newMemSessionStore()
+
+	var gotUser ExternalUser
+	onLogin := func(ctx context.Context, user ExternalUser) (string, error) {
+		gotUser = user
+		return "local-user-1", nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, onLogin, sessions, nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if gotUser.ID != "external-1" || gotUser.Email != "ishmael@example.com" {
+		t.Fatalf("unexpected resolved ExternalUser: %+v", gotUser)
+	}
+
+	sessionCookie := findCookie(t, rec, sessionCookieName)
+	if userID, ok, _ := sessions.Lookup(req.Context(), sessionCookie.Value); !ok || userID != "local-user-1" {
+		t.Fatalf("expected the session to resolve to local-user-1, got %q ok=%v", userID, ok)
+	}
+}
+
+func TestCallbackHandlerRejectsMissingStateCookie(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state=whatever", nil)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, failOnLogin(t), newMemSessionStore(), nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing state cookie, got %d", rec.Code)
+	}
+}
+
+func TestCallbackHandlerRejectsStateMismatch(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	stateCookie, _ := startLogin(t, providerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state=forged-state", nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, failOnLogin(t), newMemSessionStore(), nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a state mismatch, got %d", rec.Code)
+	}
+}
+
+func TestCallbackHandlerRejectsMissingCode(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	stateCookie, state := startLogin(t, providerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, failOnLogin(t), newMemSessionStore(), nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a missing code, got %d", rec.Code)
+	}
+}
+
+func TestCallbackHandlerPropagatesOnLoginError(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	stateCookie, state := startLogin(t, providerName)
+
+	onLogin := func(ctx context.Context, user ExternalUser) (string, error) {
+		return "", fmt.Errorf("account suspended")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, onLogin, newMemSessionStore(), nil).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 when onLogin fails, got %d", rec.Code)
+	}
+}
+
+func TestCallbackHandlerReturningUserSkipsOnLogin(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	accounts := newMemLinkedAccountStore()
+	accounts.links[accounts.key(providerName, "external-1")] = "local-user-1"
+
+	stateCookie, state := startLogin(t, providerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	sessions := newMemSessionStore()
+	CallbackHandler(providerName, failOnLogin(t), sessions, accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	sessionCookie := findCookie(t, rec, sessionCookieName)
+	if userID, ok, _ := sessions.Lookup(req.Context(), sessionCookie.Value); !ok || userID != "local-user-1" {
+		t.Fatalf("expected the existing link's local user, got %q ok=%v", userID, ok)
+	}
+}
+
+func TestCallbackHandlerFirstLoginRecordsLink(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	accounts := newMemLinkedAccountStore()
+	stateCookie, state := startLogin(t, providerName)
+
+	onLogin := func(ctx context.Context, user ExternalUser) (string, error) {
+		return "local-user-1", nil
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/callback/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	CallbackHandler(providerName, onLogin, newMemSessionStore(), accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if userID, ok, _ := accounts.FindLocalUser(req.Context(), providerName, "external-1"); !ok || userID != "local-user-1" {
+		t.Fatalf("expected the first login to record a link, got %q ok=%v", userID, ok)
+	}
+}
+
+// failOnLogin returns an OnLogin that fails the test if invoked, for
+// failure-path tests that should never get far enough to call it.
+func failOnLogin(t *testing.T) OnLogin {
+	return func(ctx context.Context, user ExternalUser) (string, error) {
+		t.Fatal("onLogin should not have been called")
+		return "", nil
+	}
+}
+
+// withUserID returns a copy of req carrying localUserID the way SessionAuth
+// would attach it after a successful session lookup.
+func withUserID(req *http.Request, localUserID string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), userIDKey, localUserID))
+}
+
+func TestLinkHandlerAttachesExternalAccountToCurrentUser(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	accounts := newMemLinkedAccountStore()
+	stateCookie, state := startLogin(t, providerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/link/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	req = withUserID(req, "local-user-1")
+	rec := httptest.NewRecorder()
+
+	LinkHandler(providerName, accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if userID, ok, _ := accounts.FindLocalUser(req.Context(), providerName, "external-1"); !ok || userID != "local-user-1" {
+		t.Fatalf("expected external-1 to be linked to local-user-1, got %q ok=%v", userID, ok)
+	}
+}
+
+func TestLinkHandlerRejectsUnauthenticatedRequest(t *testing.T) {
+	providerName, teardown := newTestProvider(t)
+	defer teardown()
+
+	stateCookie, state := startLogin(t, providerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/link/"+providerName+"?code=test-code&state="+state, nil)
+	req.AddCookie(stateCookie)
+	rec := httptest.NewRecorder()
+
+	LinkHandler(providerName, newMemLinkedAccountStore()).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without an authenticated session, got %d", rec.Code)
+	}
+}
+
+func TestUnlinkHandlerRejectsNonPOSTRequest(t *testing.T) {
+	accounts := newMemLinkedAccountStore()
+	accounts.links[accounts.key("github", "external-1")] = "local-user-1"
+
+	req := httptest.NewRequest(http.MethodGet, "/unlink/github?external_id=external-1", nil)
+	req = withUserID(req, "local-user-1")
+	rec := httptest.NewRecorder()
+
+	UnlinkHandler("github", accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405 for a GET request, got %d", rec.Code)
+	}
+	if _, ok, _ := accounts.FindLocalUser(req.Context(), "github", "external-1"); !ok {
+		t.Fatal("expected the link to survive a rejected GET request")
+	}
+}
+
+func TestUnlinkHandlerRejectsUnauthenticatedRequest(t *testing.T) {
+	accounts := newMemLinkedAccountStore()
+
+	req := httptest.NewRequest(http.MethodPost, "/unlink/github", strings.NewReader("external_id=external-1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+
+	UnlinkHandler("github", accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without an authenticated session, got %d", rec.Code)
+	}
+}
+
+func TestUnlinkHandlerRejectsUnownedLink(t *testing.T) {
+	accounts := newMemLinkedAccountStore()
+	accounts.links[accounts.key("github", "external-1")] = "local-user-2"
+
+	req := httptest.NewRequest(http.MethodPost, "/unlink/github", strings.NewReader("external_id=external-1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = withUserID(req, "local-user-1")
+	rec := httptest.NewRecorder()
+
+	UnlinkHandler("github", accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when the link belongs to a different user, got %d", rec.Code)
+	}
+	if _, ok, _ := accounts.FindLocalUser(req.Context(), "github", "external-1"); !ok {
+		t.Fatal("expected the other user's link to survive")
+	}
+}
+
+func TestUnlinkHandlerRemovesOwnedLink(t *testing.T) {
+	accounts := newMemLinkedAccountStore()
+	accounts.links[accounts.key("github", "external-1")] = "local-user-1"
+
+	req := httptest.NewRequest(http.MethodPost, "/unlink/github", strings.NewReader("external_id=external-1"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req = withUserID(req, "local-user-1")
+	rec := httptest.NewRecorder()
+
+	UnlinkHandler("github", accounts).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok, _ := accounts.FindLocalUser(req.Context(), "github", "external-1"); ok {
+		t.Fatal("expected the link to be removed")
+	}
+}
@@ -0,0 +1,83 @@
+// Package oauth2 implements the OAuth2 authorization-code flow as an HTTP
+// consumer (i.e. this package lets an application log users in via a
+// third-party provider such as GitHub or Google; it does not implement an
+// OAuth2 authorization server).
+package oauth2
+
+import "sync"
+
+// ExternalUser is the normalized identity returned by a provider's userinfo
+// (or ID token) response.
+type ExternalUser struct {
+	// Provider is the name the provider was registered under.
+	Provider string
+	// ID is the provider's stable, unique identifier for the user (its
+	// "sub" claim, or equivalent).
+	ID    string
+	Email string
+	Name  string
+	// Raw is the undecoded userinfo/claims map, for callers that need
+	// provider-specific fields ClaimsMapper didn't normalize.
+	Raw map[string]interface{}
+}
+
+// ClaimsMapper normalizes a provider's raw userinfo (or ID token claims)
+// response into an ExternalUser.
+type ClaimsMapper func(raw map[string]interface{}) ExternalUser
+
+// Provider describes an OAuth2 identity provider: where to send users to
+// authorize, where to exchange a code for a token, and how to turn the
+// resulting token into an ExternalUser.
+type Provider struct {
+	Name string
+
+	ClientID     string
+	ClientSecret string
+
+	// Issuer is the expected "iss" claim of this provider's ID tokens. Only
+	// set (and checked) for providers built via DiscoverOIDC.
+	Issuer string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+
+	// RedirectURL is the callback URL registered with the provider; it is
+	// sent as redirect_uri in both the authorize request and the token
+	// exchange.
+	RedirectURL string
+
+	Scopes []string
+
+	// ClaimsMapper normalizes the provider's userinfo response. Required
+	// unless the provider was built via DiscoverOIDC, which can instead
+	// normalize verified ID token claims.
+	ClaimsMapper ClaimsMapper
+
+	// jwks caches the provider's JSON Web Key Set for ID token
+	// verification. Only set for providers built via DiscoverOIDC.
+	jwks *JWKSCache
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*Provider{}
+)
+
+// Register makes p available under name for LoginHandler and
+// CallbackHandler. It is typically called once at startup for each
+// provider the application supports.
+func Register(name string, p *Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	p.Name = name
+	registry[name] = p
+}
+
+// lookup returns the provider registered under name.
+func lookup(name string) (*Provider, bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	p, ok := registry[name]
+	return p, ok
+}
@@ -0,0 +1,89 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Masterminds/httputil/auth"
+)
+
+type userIDKeyType struct{}
+
+var userIDKey = userIDKeyType{}
+
+// UserIDFromContext returns the local user ID attached by SessionAuth, or
+// false if ctx did not pass through a successfully authenticated session.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(userIDKey).(string)
+	return id, ok
+}
+
+// SessionAuth is an auth.AuthN provider backed by a SessionStore, letting a
+// session cookie established by CallbackHandler satisfy
+// auth.HTTPAuth.Authenticate for subsequent requests, the same way a
+// Basic/Digest/Bearer Authorization header would.
+//
+// Because sessions travel as cookies rather than an Authorization header,
+// SessionAuth is meant to be used together with its CookieMiddleware, which
+// copies the session cookie into the Authorization header under the
+// "Session" scheme before auth.HTTPAuth.Authenticate ever sees the request.
+type SessionAuth struct {
+	Sessions   SessionStore
+	CookieName string
+}
+
+// NewSessionHTTPAuth creates a new auth.HTTPAuth backed by sessions.
+func NewSessionHTTPAuth(sessions SessionStore) *auth.HTTPAuth {
+	return auth.NewHTTPAuth(&SessionAuth{Sessions: sessions})
+}
+
+func (a *SessionAuth) cookieName() string {
+	if a.CookieName == "" {
+		return sessionCookieName
+	}
+	return a.CookieName
+}
+
+// Scheme identifies this provider as "Session".
+func (a *SessionAuth) Scheme() string {
+	return "Session"
+}
+
+// Challenge returns this provider's WWW-Authenticate contribution.
+func (a *SessionAuth) Challenge(realm string) string {
+	return `Session realm="` + realm + `"`
+}
+
+// Authenticate looks up data (the session ID) in Sessions and, on success,
+// attaches the resolved local user ID to req's context.
+func (a *SessionAuth) Authenticate(req *http.Request, data string) (bool, error) {
+	sessionID := strings.TrimSpace(data)
+	if sessionID == "" {
+		return false, nil
+	}
+
+	userID, ok, err := a.Sessions.Lookup(req.Context(), sessionID)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	ctx := context.WithValue(req.Context(), userIDKey, userID)
+	*req = *req.WithContext(ctx)
+	return true, nil
+}
+
+// CookieMiddleware copies a's session cookie, if present, into the
+// Authorization header as "Session <id>" so that it is picked up by
+// auth.HTTPAuth's scheme dispatch. It should run before
+// auth.HTTPAuth.Authenticate in the middleware chain.
+func (a *SessionAuth) CookieMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			if c, err := r.Cookie(a.cookieName()); err == nil && c.Value != "" {
+				r.Header.Set("Authorization", "Session "+c.Value)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,33 @@
+package oauth2
+
+import "context"
+
+// SessionStore persists the server-side sessions created after a successful
+// OAuth2 login, so that subsequent requests can be authenticated from a
+// session cookie rather than repeating the OAuth2 dance.
+type SessionStore interface {
+	// Create starts a new session for userID and returns its identifier,
+	// which is what gets placed in the session cookie.
+	Create(ctx context.Context, userID string) (sessionID string, err error)
+	// Lookup resolves a session identifier back to the local user ID it was
+	// created for. ok is false (with a nil error) if the session does not
+	// exist or has expired.
+	Lookup(ctx context.Context, sessionID string) (userID string, ok bool, err error)
+	// Destroy ends a session, e.g. on logout.
+	Destroy(ctx context.Context, sessionID string) error
+}
+
+// LinkedAccountStore persists the mapping between a provider's external
+// account identifiers and local user accounts, so a single local user can
+// have more than one linked external identity (e.g. both GitHub and
+// Google), and so CallbackHandler can tell a first-time login from a
+// returning one.
+type LinkedAccountStore interface {
+	// Link records that externalID at provider corresponds to localUserID.
+	Link(ctx context.Context, localUserID, provider, externalID string) error
+	// FindLocalUser looks up the local user linked to an external account.
+	// ok is false (with a nil error) if no link exists yet.
+	FindLocalUser(ctx context.Context, provider, externalID string) (localUserID string, ok bool, err error)
+	// Unlink removes a previously-recorded link.
+	Unlink(ctx context.Context, provider, externalID string) error
+}
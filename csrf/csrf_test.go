@@ -0,0 +1,127 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func protectedHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+func TestProtectAllowsSafeMethodAndIssuesCookie(t *testing.T) {
+	h := Protect(Options{Secret: []byte("test-secret")})(protectedHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if len(rec.Result().Cookies()) == 0 {
+		t.Fatal("expected a CSRF cookie to be set")
+	}
+}
+
+func TestProtectRejectsUnsafeMethodWithoutToken(t *testing.T) {
+	h := Protect(Options{Secret: []byte("test-secret")})(protectedHandler())
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestProtectAllowsUnsafeMethodWithMatchingToken(t *testing.T) {
+	opts := Options{Secret: []byte("test-secret")}
+
+	var masked string
+	capture := Protect(opts)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		masked = Token(r)
+	}))
+
+	getReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	getRec := httptest.NewRecorder()
+	capture.ServeHTTP(getRec, getReq)
+
+	cookies := getRec.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatal("expected a CSRF cookie to be set")
+	}
+
+	h := Protect(opts)(protectedHandler())
+	postReq := httptest.NewRequest(http.MethodPost, "/", nil)
+	for _, c := range cookies {
+		postReq.AddCookie(c)
+	}
+	postReq.Header.Set("X-CSRF-Token", masked)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, postReq)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+}
+
+func TestProtectRejectsMismatchedToken(t *testing.T) {
+	opts := Options{Secret: []byte("test-secret")}
+
+	getReq := httptest.NewRequest(http.MethodGet, "/", nil)
+	getRec := httptest.NewRecorder()
+	Protect(opts)(protectedHandler()).ServeHTTP(getRec, getReq)
+
+	postReq := httptest.NewRequest(http.MethodPost, "/", nil)
+	for _, c := range getRec.Result().Cookies() {
+		postReq.AddCookie(c)
+	}
+	postReq.Header.Set("X-CSRF-Token", "not-a-valid-token")
+
+	rec := httptest.NewRecorder()
+	Protect(opts)(protectedHandler()).ServeHTTP(rec, postReq)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestMaskUnmaskRoundTrip(t *testing.T) {
+	raw := []byte("0123456789abcdef0123456789abcdef")[:tokenSize]
+
+	masked, err := maskToken(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := unmaskToken(masked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(raw) {
+		t.Errorf("expected unmasked token to round-trip, got %q want %q", got, raw)
+	}
+
+	// Two maskings of the same raw token should differ (the whole point of
+	// the one-time pad), but both must unmask to the same value.
+	masked2, err := maskToken(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if masked == masked2 {
+		t.Error("expected distinct masks across calls")
+	}
+	got2, err := unmaskToken(masked2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got2) != string(raw) {
+		t.Errorf("expected second masking to unmask to the same token, got %q want %q", got2, raw)
+	}
+}
@@ -0,0 +1,52 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// tokenSize is the length, in bytes, of the random token value itself
+// (independent of the mask applied before it is exposed to templates).
+const tokenSize = 32
+
+// maskToken XORs raw with a freshly generated one-time pad and returns the
+// base64-encoded concatenation of mask and masked token. Masking the token
+// differently on every render defeats BREACH-style compression oracle
+// attacks, which rely on the secret appearing at a fixed byte offset
+// across requests.
+func maskToken(raw []byte) (string, error) {
+	mask := make([]byte, tokenSize)
+	if _, err := rand.Read(mask); err != nil {
+		return "", err
+	}
+
+	masked := xor(mask, raw)
+
+	buf := make([]byte, 0, len(mask)+len(masked))
+	buf = append(buf, mask...)
+	buf = append(buf, masked...)
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// unmaskToken reverses maskToken, recovering the original raw token.
+func unmaskToken(encoded string) ([]byte, error) {
+	buf, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("csrf: malformed token encoding: %s", err)
+	}
+	if len(buf) != tokenSize*2 {
+		return nil, fmt.Errorf("csrf: malformed token length")
+	}
+
+	mask, masked := buf[:tokenSize], buf[tokenSize:]
+	return xor(mask, masked), nil
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range out {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
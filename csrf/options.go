@@ -0,0 +1,95 @@
+package csrf
+
+import "net/http"
+
+// Options configures Protect.
+type Options struct {
+	// Secret signs the token cookie with HMAC-SHA256. Required: Protect
+	// panics if it is empty.
+	Secret []byte
+
+	// CookieName is the name of the cookie the signed token is stored in.
+	// Defaults to "csrf_token".
+	CookieName string
+	// CookiePath is the Path attribute of the token cookie. Defaults to "/".
+	CookiePath string
+	// CookieDomain is the Domain attribute of the token cookie.
+	CookieDomain string
+	// Secure sets the Secure attribute of the token cookie. Defaults to
+	// true; only disable this for local development over plain HTTP.
+	Secure *bool
+
+	// HeaderName is the request header unsafe methods may echo the masked
+	// token in. Defaults to "X-CSRF-Token".
+	HeaderName string
+	// FieldName is the form field unsafe methods may alternatively echo the
+	// masked token in. Defaults to "csrf_token".
+	FieldName string
+
+	// TrustedOrigins lists the origins (scheme://host[:port]) allowed to
+	// make unsafe requests, checked against the Origin header, falling back
+	// to Referer if Origin is absent. If empty, no origin check is
+	// performed beyond the token comparison.
+	TrustedOrigins []string
+
+	// SessionID, if set, binds the issued token to the caller's session (so
+	// that a token leaked from one session cannot be replayed against
+	// another) by folding its return value into the HMAC. If nil, tokens
+	// are not session-bound.
+	SessionID func(r *http.Request) string
+
+	// ErrorHandler is invoked when a request fails CSRF validation.
+	// Defaults to responding 403 Forbidden.
+	ErrorHandler http.Handler
+}
+
+func (o *Options) cookieName() string {
+	if o.CookieName == "" {
+		return "csrf_token"
+	}
+	return o.CookieName
+}
+
+func (o *Options) cookiePath() string {
+	if o.CookiePath == "" {
+		return "/"
+	}
+	return o.CookiePath
+}
+
+func (o *Options) secure() bool {
+	if o.Secure == nil {
+		return true
+	}
+	return *o.Secure
+}
+
+func (o *Options) headerName() string {
+	if o.HeaderName == "" {
+		return "X-CSRF-Token"
+	}
+	return o.HeaderName
+}
+
+func (o *Options) fieldName() string {
+	if o.FieldName == "" {
+		return "csrf_token"
+	}
+	return o.FieldName
+}
+
+func (o *Options) sessionID(r *http.Request) string {
+	if o.SessionID == nil {
+		return ""
+	}
+	return o.SessionID(r)
+}
+
+func (o *Options) errorHandler() http.Handler {
+	if o.ErrorHandler != nil {
+		return o.ErrorHandler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "CSRF token invalid or missing", http.StatusForbidden)
+	})
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CORSOptions configures the CORS middleware.
+type CORSOptions struct {
+	// AllowedOrigins is the list of origins permitted to make cross-origin
+	// requests. "*" allows any origin.
+	AllowedOrigins []string
+	// AllowedMethods is the list of methods advertised in
+	// Access-Control-Allow-Methods for preflight requests.
+	AllowedMethods []string
+	// AllowedHeaders is the list of headers advertised in
+	// Access-Control-Allow-Headers for preflight requests.
+	AllowedHeaders []string
+	// AllowCredentials sets Access-Control-Allow-Credentials: true. When
+	// AllowedOrigins contains "*", the request's Origin is echoed back in
+	// Access-Control-Allow-Origin instead of "*".
+	AllowCredentials bool
+	// MaxAge sets Access-Control-Max-Age, in seconds, for how long a
+	// preflight response may be cached by the client.
+	MaxAge int
+}
+
+// CORS returns a middleware that handles Cross-Origin Resource Sharing
+// according to opts, answering preflight OPTIONS requests directly and
+// adding the appropriate Access-Control-* headers to actual requests.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !originAllowed(opts.AllowedOrigins, origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			if containsOrigin(opts.AllowedOrigins, "*") && !opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				h.Add("Vary", "Origin")
+			}
+			if opts.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method == http.MethodOptions {
+				if len(opts.AllowedMethods) > 0 {
+					h.Set("Access-Control-Allow-Methods", strings.Join(opts.AllowedMethods, ", "))
+				}
+				if len(opts.AllowedHeaders) > 0 {
+					h.Set("Access-Control-Allow-Headers", strings.Join(opts.AllowedHeaders, ", "))
+				}
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(opts.MaxAge))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func originAllowed(allowed []string, origin string) bool {
+	return containsOrigin(allowed, "*") || containsOrigin(allowed, origin)
+}
+
+func containsOrigin(allowed []string, origin string) bool {
+	for _, a := range allowed {
+		if a == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+// Package middleware provides a set of common http.Handler middleware for
+// use with httputil.Mux (or any router that accepts a
+// func(http.Handler) http.Handler chain).
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// responseWriter wraps an http.ResponseWriter to capture the status code
+// and byte count written, for use by Logging and similar middleware that
+// need to report on the response after the handler has run.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Logging returns a middleware that logs each request's method, path,
+// status code, response size, and duration to logger using logger.Printf.
+func Logging(logger *log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rw := &responseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+			logger.Printf("%s %s %d %dB %s", r.Method, r.URL.Path, rw.status, rw.bytes, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP returns a middleware that rewrites r.RemoteAddr to the client's
+// real IP address as reported by the Forwarded or X-Forwarded-For header,
+// for use behind a reverse proxy or load balancer. Forwarded is preferred
+// over X-Forwarded-For when both are present, per RFC 7239. If neither
+// header is present, r.RemoteAddr is left untouched.
+//
+// This trusts whatever proxy sits in front of it to set these headers
+// correctly; it should only be used when that proxy is not itself
+// attacker-controlled, otherwise a client can simply forge the header.
+func RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := forwardedFor(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func forwardedFor(r *http.Request) string {
+	if fwd := r.Header.Get("Forwarded"); fwd != "" {
+		for _, part := range strings.Split(fwd, ";") {
+			part = strings.TrimSpace(part)
+			if strings.HasPrefix(strings.ToLower(part), "for=") {
+				val := strings.Trim(part[len("for="):], `"`)
+				if host, _, err := net.SplitHostPort(val); err == nil {
+					return host
+				}
+				return val
+			}
+		}
+	}
+
+	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+		first := strings.TrimSpace(strings.Split(xff, ",")[0])
+		if first != "" {
+			return first
+		}
+	}
+
+	return ""
+}
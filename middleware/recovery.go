@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recoverer returns a middleware that recovers from panics in downstream
+// handlers, logs the panic value and a stack trace to logger, and responds
+// with a 500 Internal Server Error instead of letting the panic unwind
+// through net/http (which would otherwise just close the connection).
+func Recoverer(logger *log.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					logger.Printf("panic: %v\n%s", rec, debug.Stack())
+					http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
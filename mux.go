@@ -0,0 +1,99 @@
+package httputil
+
+import "net/http"
+
+// MiddlewareFunc wraps an http.Handler to produce a new http.Handler, adding
+// behavior before and/or after the wrapped handler runs.
+type MiddlewareFunc func(http.Handler) http.Handler
+
+// Mux is an http.Handler that dispatches requests to registered handlers
+// using a Router, and supports a middleware pipeline applied globally or to
+// a subgroup of routes sharing a common prefix.
+//
+// Where Resolver and Router only answer "which pattern matches this
+// request", Mux also owns the handler for each pattern, turning this package
+// into a usable (if minimal) routing framework.
+type Mux struct {
+	router     *Router
+	handlers   map[string]http.Handler
+	middleware []MiddlewareFunc
+	prefix     string
+}
+
+// NewMux creates an empty Mux with no registered routes or middleware.
+func NewMux() *Mux {
+	return &Mux{
+		router:   NewRouter(),
+		handlers: map[string]http.Handler{},
+	}
+}
+
+// Use appends one or more middleware to the chain applied to every route
+// registered on m (or, for a group, every route registered on that group)
+// from this point forward. Middleware are applied in the order they were
+// added, so the first one registered is the outermost wrapper.
+func (m *Mux) Use(mw ...MiddlewareFunc) {
+	m.middleware = append(m.middleware, mw...)
+}
+
+// Handle registers h to serve pattern, a "VERB /path" string using the same
+// syntax as Router.Add. Middleware added via Use (including any inherited
+// from an enclosing Group) are applied to h at registration time.
+func (m *Mux) Handle(pattern string, h http.Handler) {
+	verb, p, ok := splitVerb(pattern)
+	if !ok {
+		verb, p = "*", pattern
+	}
+
+	full := verb + " " + m.prefix + p
+	m.handlers[full] = applyMiddleware(h, m.middleware)
+	if err := m.router.Add(full); err != nil {
+		panic(err)
+	}
+}
+
+// HandleFunc registers f, an http.HandlerFunc, to serve pattern. See Handle.
+func (m *Mux) HandleFunc(pattern string, f http.HandlerFunc) {
+	m.Handle(pattern, f)
+}
+
+// Group creates a subgroup of routes under prefix, inheriting m's current
+// middleware stack. fn is called with the group's Mux so the caller can add
+// group-scoped middleware via Use and register routes via Handle/HandleFunc
+// before Group returns. Routes registered in the group share m's underlying
+// Router and handler table, so they participate in the same dispatch.
+func (m *Mux) Group(prefix string, fn func(*Mux)) {
+	g := &Mux{
+		router:     m.router,
+		handlers:   m.handlers,
+		middleware: append([]MiddlewareFunc(nil), m.middleware...),
+		prefix:     m.prefix + prefix,
+	}
+	fn(g)
+}
+
+// ServeHTTP implements http.Handler by resolving req against the underlying
+// Router and dispatching to the matched handler. Unmatched requests receive
+// a 404 via http.NotFound.
+func (m *Mux) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	pattern, _, req, err := m.router.Resolve(req)
+	if err != nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	h, ok := m.handlers[pattern]
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	h.ServeHTTP(w, req)
+}
+
+// applyMiddleware wraps h with mw in order, so that mw[0] is outermost.
+func applyMiddleware(h http.Handler, mw []MiddlewareFunc) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
@@ -0,0 +1,99 @@
+package httputil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMuxHandle(t *testing.T) {
+	m := NewMux()
+	m.HandleFunc("GET /users/:id", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user:" + Param(r, "id")))
+	})
+
+	req := httptest.NewRequest("GET", "/users/pip", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if got := rec.Body.String(); got != "user:pip" {
+		t.Errorf("expected %q, got %q", "user:pip", got)
+	}
+}
+
+func TestMuxMiddlewareOrder(t *testing.T) {
+	var order []string
+	track := func(name string) MiddlewareFunc {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	m := NewMux()
+	m.Use(track("outer"), track("inner"))
+	m.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	})
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	m.ServeHTTP(httptest.NewRecorder(), req)
+
+	want := []string{"outer", "inner", "handler"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Errorf("expected %v, got %v", want, order)
+			break
+		}
+	}
+}
+
+func TestMuxGroupInheritsMiddlewareAndPrefix(t *testing.T) {
+	var hit, parentHit bool
+	m := NewMux()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			parentHit = true
+			next.ServeHTTP(w, r)
+		})
+	})
+	m.Group("/api", func(g *Mux) {
+		g.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hit = true
+				next.ServeHTTP(w, r)
+			})
+		})
+		g.HandleFunc("GET /ping", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	req := httptest.NewRequest("GET", "/api/ping", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+	if !hit {
+		t.Error("expected group middleware to run")
+	}
+	if !parentHit {
+		t.Error("expected middleware inherited from the parent mux to run")
+	}
+}
+
+func TestMuxNotFound(t *testing.T) {
+	m := NewMux()
+	req := httptest.NewRequest("GET", "/nope", nil)
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
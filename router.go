@@ -0,0 +1,322 @@
+package httputil
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// paramsKey is the context key under which a matched route's Params are stored.
+type paramsKeyType struct{}
+
+var paramsKey = paramsKeyType{}
+
+// Params holds the named parameters extracted while resolving a path against
+// the Router's trie, keyed by parameter name (without the leading `:`/`*`).
+type Params map[string]string
+
+// Param returns the value of the named parameter that was extracted while
+// resolving req's route, or "" if there is no such parameter or req was
+// never resolved through a Router.
+func Param(req *http.Request, name string) string {
+	params, ok := req.Context().Value(paramsKey).(Params)
+	if !ok {
+		return ""
+	}
+	return params[name]
+}
+
+// nodeKind distinguishes the three flavors of trie node. At any given node,
+// static children are preferred over param children, which are preferred
+// over a catch-all child, matching the precedence rules of routers such as
+// httprouter and chi.
+type nodeKind int
+
+const (
+	staticNode nodeKind = iota
+	paramNode
+	catchAllNode
+)
+
+// node is a single segment of a routing trie. The tree is keyed on HTTP verb
+// at the root, then on path segments split by "/".
+type node struct {
+	kind     nodeKind
+	segment  string         // literal text for staticNode, parameter name otherwise
+	pattern  *regexp.Regexp // optional constraint for paramNode, e.g. {id:[0-9]+}
+	children []*node
+	params   []*node // paramNode children; more than one when routes place differently-constrained params at the same position
+	catchAll *node   // the single catchAllNode child, if any
+	route    string
+	isLeaf   bool
+}
+
+// Router resolves HTTP requests against a set of registered route patterns
+// using a radix-style trie, rather than the linear scan used by Resolver.
+//
+// Supported segment syntax:
+//
+//   - Static segments match literally: "/users/list".
+//   - Named parameters match a single path segment and capture it:
+//     "/users/:id".
+//   - Named parameters with a regex constraint: "/users/{id:[0-9]+}".
+//   - A catch-all suffix matches the remainder of the path, including
+//     slashes: "/files/*filepath".
+//   - The legacy "**" and "/**" wildcards from Resolver are accepted as a
+//     compatibility layer and compiled down to an equivalent catch-all node.
+//
+// At each node, static children are tried first, then parameterized
+// children, then the catch-all child, so lookup walks down the trie one
+// path segment at a time rather than scanning every route. Lookup is not
+// guaranteed to be O(len(path)): when a branch fails deeper down, walk
+// backtracks and tries the next alternative at that node.
+//
+// Router is built once via NewRouter/Add and is safe for concurrent use for
+// resolution afterwards, matching the concurrency contract of Resolver.
+type Router struct {
+	verbs map[string]*node
+	names map[string]*namedRoute
+}
+
+// NewRouter creates an empty Router. Use Add to register routes.
+func NewRouter() *Router {
+	return &Router{verbs: map[string]*node{}}
+}
+
+// Add registers a verb+pattern route, such as "GET /users/:id" or
+// "* /files/*filepath". The verb "*" matches any method, as in Resolver.
+func (rt *Router) Add(pattern string) error {
+	verb, p, ok := splitVerb(pattern)
+	if !ok {
+		// The bare "**" wildcard has no verb prefix, matching any verb and
+		// any path at all, per Resolver's documented semantics.
+		if pattern != "**" {
+			return errBadPattern(pattern)
+		}
+		verb, p = "*", pattern
+	}
+
+	p = compileLegacyWildcard(p)
+
+	root, ok := rt.verbs[verb]
+	if !ok {
+		root = &node{kind: staticNode}
+		rt.verbs[verb] = root
+	}
+
+	segs := splitSegments(p)
+	cur := root
+	for i, seg := range segs {
+		last := i == len(segs)-1
+
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			name := seg[1:]
+			if !last {
+				return errBadPattern(pattern)
+			}
+			if cur.catchAll == nil {
+				cur.catchAll = &node{kind: catchAllNode, segment: name}
+			}
+			cur = cur.catchAll
+
+		case strings.HasPrefix(seg, ":"):
+			cur = addParamChild(cur, seg[1:], nil)
+
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			name, re, err := parseConstraint(seg)
+			if err != nil {
+				return err
+			}
+			cur = addParamChild(cur, name, re)
+
+		default:
+			cur = addStaticChild(cur, seg)
+		}
+	}
+
+	cur.isLeaf = true
+	cur.route = pattern
+	return nil
+}
+
+// addStaticChild finds or creates a static child of n labeled seg.
+func addStaticChild(n *node, seg string) *node {
+	for _, c := range n.children {
+		if c.kind == staticNode && c.segment == seg {
+			return c
+		}
+	}
+	child := &node{kind: staticNode, segment: seg}
+	n.children = append(n.children, child)
+	return child
+}
+
+// addParamChild finds or creates a param child of n matching name and re.
+// A node may have more than one param child when different routes place
+// differently-constrained parameters at the same trie position (e.g.
+// "/users/:id" alongside "/users/{id:[0-9]+}/profile"); walk tries each
+// alternative in turn and backtracks, so the constraint on one route can't
+// silently leak onto, or be shadowed by, the other.
+func addParamChild(n *node, name string, re *regexp.Regexp) *node {
+	for _, c := range n.params {
+		if c.segment == name && samePattern(c.pattern, re) {
+			return c
+		}
+	}
+	child := &node{kind: paramNode, segment: name, pattern: re}
+	n.params = append(n.params, child)
+	return child
+}
+
+func samePattern(a, b *regexp.Regexp) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.String() == b.String()
+}
+
+// Resolve resolves req's method and path against the trie, returning the
+// matched pattern string and the extracted Params. It also attaches Params
+// to req's context so that Param(req, name) can retrieve them downstream.
+func (rt *Router) Resolve(req *http.Request) (string, Params, *http.Request, error) {
+	pattern, params, err := rt.ResolvePath(req.Method, req.URL.Path)
+	if err != nil {
+		return pattern, params, req, err
+	}
+	req = req.WithContext(context.WithValue(req.Context(), paramsKey, params))
+	return pattern, params, req, nil
+}
+
+// ResolvePath resolves a bare verb and path against the trie without
+// requiring an *http.Request.
+func (rt *Router) ResolvePath(verb, reqPath string) (string, Params, error) {
+	segs := splitSegments(reqPath)
+
+	if root, ok := rt.verbs[verb]; ok {
+		if pattern, params, ok := walk(root, segs, Params{}); ok {
+			return pattern, params, nil
+		}
+	}
+
+	if verb != "*" {
+		if root, ok := rt.verbs["*"]; ok {
+			if pattern, params, ok := walk(root, segs, Params{}); ok {
+				return pattern, params, nil
+			}
+		}
+	}
+
+	return reqPath, nil, ErrRouteNotFound
+}
+
+// walk descends the trie matching segs against n's children, preferring
+// static matches, then parameterized matches, then catch-all.
+func walk(n *node, segs []string, params Params) (string, Params, bool) {
+	if len(segs) == 0 {
+		if n.isLeaf {
+			return n.route, params, true
+		}
+		return "", nil, false
+	}
+
+	seg, rest := segs[0], segs[1:]
+
+	for _, c := range n.children {
+		if c.kind == staticNode && c.segment == seg {
+			if pattern, p, ok := walk(c, rest, params); ok {
+				return pattern, p, true
+			}
+		}
+	}
+
+	for _, c := range n.params {
+		if c.pattern != nil && !c.pattern.MatchString(seg) {
+			continue
+		}
+		next := cloneParams(params)
+		next[c.segment] = seg
+		if pattern, p, ok := walk(c, rest, next); ok {
+			return pattern, p, true
+		}
+	}
+
+	if n.catchAll != nil && n.catchAll.isLeaf {
+		next := cloneParams(params)
+		next[n.catchAll.segment] = strings.Join(segs, "/")
+		return n.catchAll.route, next, true
+	}
+
+	return "", nil, false
+}
+
+func cloneParams(p Params) Params {
+	next := make(Params, len(p)+1)
+	for k, v := range p {
+		next[k] = v
+	}
+	return next
+}
+
+// splitVerb separates the leading HTTP verb from the path portion of a
+// pattern, mirroring Resolver's "VERB /path" convention.
+func splitVerb(pattern string) (verb, p string, ok bool) {
+	idx := strings.IndexByte(pattern, ' ')
+	if idx < 0 {
+		return "", "", false
+	}
+	return pattern[:idx], pattern[idx+1:], true
+}
+
+// splitSegments splits a path into its non-empty segments.
+func splitSegments(p string) []string {
+	parts := strings.Split(p, "/")
+	segs := make([]string, 0, len(parts))
+	for _, s := range parts {
+		if s != "" {
+			segs = append(segs, s)
+		}
+	}
+	return segs
+}
+
+// parseConstraint parses a "{name:regex}" segment into its name and
+// compiled regex.
+func parseConstraint(seg string) (string, *regexp.Regexp, error) {
+	inner := seg[1 : len(seg)-1]
+	name, pat, ok := strings.Cut(inner, ":")
+	if !ok || name == "" || pat == "" {
+		return "", nil, errBadPattern(seg)
+	}
+	re, err := regexp.Compile("^" + pat + "$")
+	if err != nil {
+		return "", nil, err
+	}
+	return name, re, nil
+}
+
+// compileLegacyWildcard rewrites Resolver's "**" and "/**" wildcard
+// suffixes into the equivalent "*wildcard" catch-all segment, so the
+// existing wildcard syntax keeps working once routes are registered on a
+// Router.
+func compileLegacyWildcard(p string) string {
+	switch {
+	case p == "**":
+		return "*wildcard"
+	case strings.HasSuffix(p, "/**"):
+		return strings.TrimSuffix(p, "/**") + "/*wildcard"
+	default:
+		return p
+	}
+}
+
+func errBadPattern(pattern string) error {
+	return &badPatternError{pattern}
+}
+
+type badPatternError struct{ pattern string }
+
+func (e *badPatternError) Error() string {
+	return "httputil: illegal route pattern: " + e.pattern
+}
@@ -0,0 +1,138 @@
+package httputil
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// routeSegment is the reverse-routing counterpart of a trie node: enough
+// information to reconstruct one path segment given a set of parameters.
+type routeSegment struct {
+	kind    nodeKind
+	literal string         // for staticNode
+	name    string         // for paramNode and catchAllNode
+	pattern *regexp.Regexp // optional constraint, for paramNode
+}
+
+// namedRoute is a route pattern registered under a name via Router.Named,
+// parsed into routeSegments so Router.URL can walk it without re-parsing
+// the pattern string on every call.
+type namedRoute struct {
+	pattern string
+	segs    []routeSegment
+}
+
+// Named registers pattern (using the same "VERB /path" syntax as
+// Router.Add) under name, both adding it to the trie for matching and
+// recording it for reverse lookup via URL/URLValues.
+func (rt *Router) Named(name, pattern string) error {
+	_, p, ok := splitVerb(pattern)
+	if !ok {
+		if pattern != "**" {
+			return errBadPattern(pattern)
+		}
+		p = pattern
+	}
+	p = compileLegacyWildcard(p)
+
+	segs := splitSegments(p)
+	parsed := make([]routeSegment, 0, len(segs))
+	for i, seg := range segs {
+		last := i == len(segs)-1
+
+		switch {
+		case strings.HasPrefix(seg, "*"):
+			if !last {
+				return errBadPattern(pattern)
+			}
+			parsed = append(parsed, routeSegment{kind: catchAllNode, name: seg[1:]})
+
+		case strings.HasPrefix(seg, ":"):
+			parsed = append(parsed, routeSegment{kind: paramNode, name: seg[1:]})
+
+		case strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}"):
+			paramName, re, err := parseConstraint(seg)
+			if err != nil {
+				return err
+			}
+			parsed = append(parsed, routeSegment{kind: paramNode, name: paramName, pattern: re})
+
+		default:
+			parsed = append(parsed, routeSegment{kind: staticNode, literal: seg})
+		}
+	}
+
+	if err := rt.Add(pattern); err != nil {
+		return err
+	}
+
+	if rt.names == nil {
+		rt.names = map[string]*namedRoute{}
+	}
+	rt.names[name] = &namedRoute{pattern: pattern, segs: parsed}
+	return nil
+}
+
+// URL reconstructs the path for the route registered as name, substituting
+// params into its :name/{name}/*name placeholders. It returns an error if
+// name is unknown, a required parameter is missing, or a value fails its
+// regex constraint.
+func (rt *Router) URL(name string, params map[string]string) (string, error) {
+	nr, ok := rt.names[name]
+	if !ok {
+		return "", fmt.Errorf("httputil: no route named %q", name)
+	}
+
+	var b strings.Builder
+	for _, seg := range nr.segs {
+		b.WriteByte('/')
+
+		switch seg.kind {
+		case staticNode:
+			b.WriteString(seg.literal)
+
+		case paramNode:
+			v, ok := params[seg.name]
+			if !ok || v == "" {
+				return "", fmt.Errorf("httputil: missing required parameter %q for route %q", seg.name, name)
+			}
+			if seg.pattern != nil && !seg.pattern.MatchString(v) {
+				return "", fmt.Errorf("httputil: parameter %q=%q does not satisfy constraint for route %q", seg.name, v, name)
+			}
+			b.WriteString(url.PathEscape(v))
+
+		case catchAllNode:
+			v, ok := params[seg.name]
+			if !ok || v == "" {
+				return "", fmt.Errorf("httputil: missing required parameter %q for route %q", seg.name, name)
+			}
+			b.WriteString(escapeCatchAll(v))
+		}
+	}
+
+	return b.String(), nil
+}
+
+// URLValues is like URL, but appends query as a URL-encoded query string.
+func (rt *Router) URLValues(name string, params map[string]string, query url.Values) (string, error) {
+	p, err := rt.URL(name, params)
+	if err != nil {
+		return "", err
+	}
+	if len(query) == 0 {
+		return p, nil
+	}
+	return p + "?" + query.Encode(), nil
+}
+
+// escapeCatchAll path-escapes a catch-all parameter value segment by
+// segment, preserving its internal slashes.
+func escapeCatchAll(v string) string {
+	parts := strings.Split(v, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
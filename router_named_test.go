@@ -0,0 +1,74 @@
+package httputil
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestRouterNamedURL(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Named("user", "GET /users/:id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Named("user-files", "GET /users/:id/files/*filepath"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := rt.URL("user", map[string]string{"id": "ahab 1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/ahab%201"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	got, err = rt.URL("user-files", map[string]string{"id": "ahab", "filepath": "a/b/c.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/ahab/files/a/b/c.txt"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	if _, err := rt.URL("user", map[string]string{}); err == nil {
+		t.Error("expected error for missing required parameter")
+	}
+
+	if _, err := rt.URL("nope", nil); err == nil {
+		t.Error("expected error for unknown route name")
+	}
+}
+
+func TestRouterNamedURLValues(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Named("user", "GET /users/:id"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := rt.URLValues("user", map[string]string{"id": "queequeg"}, url.Values{"tab": {"profile"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/queequeg?tab=profile"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRouterNamedConstraintValidatesParam(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Named("user", "GET /users/{id:[0-9]+}"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rt.URL("user", map[string]string{"id": "not-a-number"}); err == nil {
+		t.Error("expected error for parameter failing its constraint")
+	}
+
+	got, err := rt.URL("user", map[string]string{"id": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/42"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
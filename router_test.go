@@ -0,0 +1,186 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRouter(t *testing.T) {
+	rt := NewRouter()
+	routes := []string{
+		"* /loomings",
+		"THE /carpet/bag",
+		"* /carpet/bag",
+		"GET /users/:id",
+		"GET /users/{id:[0-9]+}/profile",
+		"GET /files/*filepath",
+		"THE /counterpane/**",
+		"* /enter/**",
+		"**",
+	}
+	for _, r := range routes {
+		if err := rt.Add(r); err != nil {
+			t.Fatalf("Add(%q): %s", r, err)
+		}
+	}
+
+	tests := []struct {
+		give       string
+		wantRoute  string
+		wantParams Params
+	}{
+		{"GET /loomings", "* /loomings", nil},
+		{"THE /carpet/bag", "THE /carpet/bag", nil},
+		{"POST /carpet/bag", "* /carpet/bag", nil},
+		{"GET /users/ishmael", "GET /users/:id", Params{"id": "ishmael"}},
+		{"GET /users/42/profile", "GET /users/{id:[0-9]+}/profile", Params{"id": "42"}},
+		{"GET /files/a/b/c.txt", "GET /files/*filepath", Params{"filepath": "a/b/c.txt"}},
+		{"THE /counterpane/1/2/3", "THE /counterpane/**", Params{"wildcard": "1/2/3"}},
+		{"GET /enter/ahab/pipe", "* /enter/**", Params{"wildcard": "ahab/pipe"}},
+		{"THIS /SHOULD/match/ANYTHING", "**", Params{"wildcard": "SHOULD/match/ANYTHING"}},
+	}
+
+	for _, tt := range tests {
+		verb, p, _ := splitVerb(tt.give)
+		route, params, err := rt.ResolvePath(verb, p)
+		if err != nil {
+			t.Errorf("ResolvePath(%q): unexpected error %s", tt.give, err)
+			continue
+		}
+		if route != tt.wantRoute {
+			t.Errorf("ResolvePath(%q): expected route %q, got %q", tt.give, tt.wantRoute, route)
+		}
+		for k, v := range tt.wantParams {
+			if params[k] != v {
+				t.Errorf("ResolvePath(%q): expected param %s=%q, got %q", tt.give, k, v, params[k])
+			}
+		}
+	}
+}
+
+func TestRouterParamConstraintRejectsNonMatch(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Add("GET /users/{id:[0-9]+}"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := rt.ResolvePath("GET", "/users/not-a-number"); err != ErrRouteNotFound {
+		t.Errorf("expected ErrRouteNotFound for non-matching constraint, got %v", err)
+	}
+}
+
+func TestRouterCoexistingParamConstraintsAtSamePosition(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Add("GET /users/:id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Add("GET /users/{id:[0-9]+}/profile"); err != nil {
+		t.Fatal(err)
+	}
+
+	route, params, err := rt.ResolvePath("GET", "/users/notanumber")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != "GET /users/:id" {
+		t.Errorf("expected the unconstrained route, got %q", route)
+	}
+	if params["id"] != "notanumber" {
+		t.Errorf("expected id=notanumber, got %q", params["id"])
+	}
+
+	if _, _, err := rt.ResolvePath("GET", "/users/notanumber/profile"); err != ErrRouteNotFound {
+		t.Errorf("expected ErrRouteNotFound for a non-numeric id on the constrained route, got %v", err)
+	}
+
+	route, params, err = rt.ResolvePath("GET", "/users/42/profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != "GET /users/{id:[0-9]+}/profile" {
+		t.Errorf("expected the constrained route, got %q", route)
+	}
+	if params["id"] != "42" {
+		t.Errorf("expected id=42, got %q", params["id"])
+	}
+}
+
+func TestRouterStaticPreferredOverParam(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Add("GET /users/:id"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Add("GET /users/me"); err != nil {
+		t.Fatal(err)
+	}
+
+	route, _, err := rt.ResolvePath("GET", "/users/me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if route != "GET /users/me" {
+		t.Errorf("expected static route to win, got %q", route)
+	}
+}
+
+func TestRouterParam(t *testing.T) {
+	rt := NewRouter()
+	if err := rt.Add("GET /users/:id"); err != nil {
+		t.Fatal(err)
+	}
+
+	u, _ := url.Parse("/users/starbuck")
+	req := &http.Request{Method: "GET", URL: u}
+	_, _, req, err := rt.Resolve(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Param(req, "id"); got != "starbuck" {
+		t.Errorf("expected id=starbuck, got %q", got)
+	}
+}
+
+// buildLargeRouteTable produces n distinct static routes so the trie and
+// the linear Resolver can be benchmarked against a realistically sized
+// table using a pattern both support identically; the trie's param-matching
+// advantage is covered separately by TestRouterCoexistingParamConstraintsAtSamePosition
+// and friends, not by this raw-throughput comparison.
+func buildLargeRouteTable(n int) []string {
+	routes := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		routes = append(routes, fmt.Sprintf("GET /resource%d/detail", i))
+	}
+	return routes
+}
+
+func BenchmarkRouterResolve(b *testing.B) {
+	routes := buildLargeRouteTable(200)
+	rt := NewRouter()
+	for _, r := range routes {
+		if err := rt.Add(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+	target := "/resource199/detail"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := rt.ResolvePath("GET", target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkResolverResolvePath(b *testing.B) {
+	routes := buildLargeRouteTable(200)
+	r := NewResolver(routes)
+	target := "GET /resource199/detail"
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.ResolvePath(target); err != nil {
+			b.Fatal(err)
+		}
+	}
+}